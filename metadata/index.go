@@ -0,0 +1,155 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"sort"
+	"strings"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PrefixIndex is a sorted-key index over a single object's labels or
+// annotations, answering prefix queries in O(log n + m) instead of the O(n)
+// full-map scan filterByPrefix performs, where n is the number of entries and
+// m is the number of matches. Build one with NewLabelIndex/NewAnnotationIndex
+// and reuse it across the many prefix lookups a reconcile pass typically
+// makes against the same object.
+type PrefixIndex struct {
+	keys    []string
+	entries map[string]string
+	target  func(v1.Object) map[string]string
+}
+
+// NewLabelIndex builds a PrefixIndex over obj's labels. BulkCopy on the
+// returned index writes back into the destination's labels.
+func NewLabelIndex(obj v1.Object) *PrefixIndex {
+	return newPrefixIndex(obj.GetLabels(), labelsTarget)
+}
+
+// NewAnnotationIndex builds a PrefixIndex over obj's annotations. BulkCopy on
+// the returned index writes back into the destination's annotations.
+func NewAnnotationIndex(obj v1.Object) *PrefixIndex {
+	return newPrefixIndex(obj.GetAnnotations(), annotationsTarget)
+}
+
+func newPrefixIndex(entries map[string]string, target func(v1.Object) map[string]string) *PrefixIndex {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return &PrefixIndex{keys: keys, entries: entries, target: target}
+}
+
+// labelsTarget returns dest's labels map, creating it if necessary.
+func labelsTarget(dest v1.Object) map[string]string {
+	if dest.GetLabels() == nil {
+		dest.SetLabels(make(map[string]string))
+	}
+	return dest.GetLabels()
+}
+
+// annotationsTarget returns dest's annotations map, creating it if necessary.
+func annotationsTarget(dest v1.Object) map[string]string {
+	if dest.GetAnnotations() == nil {
+		dest.SetAnnotations(make(map[string]string))
+	}
+	return dest.GetAnnotations()
+}
+
+// bounds returns the [lo, hi) range of i.keys whose entries have the given
+// prefix. Since i.keys is sorted, every key with the prefix forms a single
+// contiguous range starting at the first key >= prefix.
+func (i *PrefixIndex) bounds(prefix string) (lo, hi int) {
+	lo = sort.SearchStrings(i.keys, prefix)
+	hi = lo
+	for hi < len(i.keys) && strings.HasPrefix(i.keys[hi], prefix) {
+		hi++
+	}
+	return lo, hi
+}
+
+// Get returns the key/value pairs whose key has the given prefix.
+func (i *PrefixIndex) Get(prefix string) map[string]string {
+	lo, hi := i.bounds(prefix)
+	result := make(map[string]string, hi-lo)
+	for _, key := range i.keys[lo:hi] {
+		result[key] = i.entries[key]
+	}
+	return result
+}
+
+// Keys returns the keys matching the given prefix, in sorted order.
+func (i *PrefixIndex) Keys(prefix string) []string {
+	lo, hi := i.bounds(prefix)
+	keys := make([]string, hi-lo)
+	copy(keys, i.keys[lo:hi])
+	return keys
+}
+
+// Has reports whether any key has the given prefix.
+func (i *PrefixIndex) Has(prefix string) bool {
+	lo, hi := i.bounds(prefix)
+	return hi > lo
+}
+
+// PrefixMapping describes a single prefix range to copy via BulkCopy, with an
+// optional rewrite of the prefix in the destination, generalizing the
+// prefix/replacementPrefix pair used by CopyLabelsByPrefix.
+type PrefixMapping struct {
+	Prefix            string
+	ReplacementPrefix string
+}
+
+// BulkCopy copies the entries matching each PrefixMapping from the index into
+// dest, in a single pass over the mappings, generalizing CopyLabelsByPrefix/
+// CopyAnnotationsByPrefix to many prefixes at once. The destination map
+// written to (dest's labels or dest's annotations) matches whichever of
+// NewLabelIndex/NewAnnotationIndex built the index.
+func (i *PrefixIndex) BulkCopy(dest v1.Object, prefixes ...PrefixMapping) {
+	destEntries := i.target(dest)
+
+	for _, mapping := range prefixes {
+		for key, val := range i.Get(mapping.Prefix) {
+			newKey := key
+			if mapping.Prefix != mapping.ReplacementPrefix {
+				newKey = strings.Replace(key, mapping.Prefix, mapping.ReplacementPrefix, 1)
+			}
+			destEntries[newKey] = val
+		}
+	}
+}
+
+// MultiPrefixFilter buckets obj's labels by each of the given prefixes in a
+// single traversal of the label map, for callers that need several prefix
+// buckets at once instead of calling GetLabelsWithPrefix once per prefix.
+func MultiPrefixFilter(obj v1.Object, prefixes []string) map[string]map[string]string {
+	buckets := make(map[string]map[string]string, len(prefixes))
+	for _, prefix := range prefixes {
+		buckets[prefix] = map[string]string{}
+	}
+
+	for key, val := range obj.GetLabels() {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, prefix) {
+				buckets[prefix][key] = val
+			}
+		}
+	}
+	return buckets
+}
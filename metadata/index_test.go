@@ -0,0 +1,129 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("PrefixIndex", func() {
+
+	pod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			Labels: map[string]string{
+				"app.kubernetes.io/name":    "widget",
+				"app.kubernetes.io/version": "1.0",
+				"olm.owner":                 "csv-a",
+				"tier":                      "frontend",
+			},
+		},
+	}
+
+	When("NewLabelIndex is called", func() {
+		It("should index all of the object's labels", func() {
+			index := NewLabelIndex(pod)
+			Expect(index.Keys("")).To(HaveLen(4))
+		})
+	})
+
+	When("Get is called", func() {
+		It("should return all entries matching the given prefix", func() {
+			index := NewLabelIndex(pod)
+			Expect(index.Get("app.kubernetes.io/")).To(gstruct.MatchAllKeys(gstruct.Keys{
+				"app.kubernetes.io/name":    Equal("widget"),
+				"app.kubernetes.io/version": Equal("1.0"),
+			}))
+		})
+
+		It("should return an empty map when nothing matches", func() {
+			index := NewLabelIndex(pod)
+			Expect(index.Get("nope")).To(BeEmpty())
+		})
+	})
+
+	When("Keys is called", func() {
+		It("should return the matching keys in sorted order", func() {
+			index := NewLabelIndex(pod)
+			Expect(index.Keys("app.kubernetes.io/")).To(Equal([]string{
+				"app.kubernetes.io/name", "app.kubernetes.io/version",
+			}))
+		})
+	})
+
+	When("Has is called", func() {
+		It("should return true when a key has the given prefix", func() {
+			index := NewLabelIndex(pod)
+			Expect(index.Has("olm.")).To(BeTrue())
+		})
+
+		It("should return false when no key has the given prefix", func() {
+			index := NewLabelIndex(pod)
+			Expect(index.Has("nope")).To(BeFalse())
+		})
+	})
+
+	When("BulkCopy is called", func() {
+		It("should copy each prefix mapping's entries in a single call", func() {
+			dest := &corev1.Pod{}
+			index := NewLabelIndex(pod)
+
+			index.BulkCopy(dest,
+				PrefixMapping{Prefix: "app.kubernetes.io/", ReplacementPrefix: "app.kubernetes.io/"},
+				PrefixMapping{Prefix: "olm.", ReplacementPrefix: "copied.olm."},
+			)
+
+			Expect(dest.Labels).To(gstruct.MatchAllKeys(gstruct.Keys{
+				"app.kubernetes.io/name":    Equal("widget"),
+				"app.kubernetes.io/version": Equal("1.0"),
+				"copied.olm.owner":          Equal("csv-a"),
+			}))
+		})
+
+		It("should write into the destination's annotations when built via NewAnnotationIndex", func() {
+			src := &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{
+					Annotations: map[string]string{"olm.owner": "csv-a"},
+				},
+			}
+			dest := &corev1.Pod{}
+			index := NewAnnotationIndex(src)
+
+			index.BulkCopy(dest, PrefixMapping{Prefix: "olm.", ReplacementPrefix: "copied.olm."})
+
+			Expect(dest.Annotations).To(gstruct.MatchAllKeys(gstruct.Keys{
+				"copied.olm.owner": Equal("csv-a"),
+			}))
+			Expect(dest.Labels).To(BeEmpty())
+		})
+	})
+
+	When("MultiPrefixFilter is called", func() {
+		It("should bucket the labels by each requested prefix", func() {
+			buckets := MultiPrefixFilter(pod, []string{"app.kubernetes.io/", "olm."})
+
+			Expect(buckets).To(HaveLen(2))
+			Expect(buckets["app.kubernetes.io/"]).To(HaveLen(2))
+			Expect(buckets["olm."]).To(gstruct.MatchAllKeys(gstruct.Keys{
+				"olm.owner": Equal("csv-a"),
+			}))
+		})
+	})
+})
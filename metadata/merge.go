@@ -0,0 +1,142 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"encoding/json"
+	"errors"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// appliedMetadata is the JSON shape persisted by SnapshotAppliedMetadata and
+// read back by LoadAppliedMetadata.
+type appliedMetadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// StrategicMergeLabels computes a three-way merge of labels, mirroring the
+// semantics `kubectl apply` uses for metadata, and returns the merged map:
+// keys added in modified are added to current's labels, keys changed in
+// modified overwrite current's values, keys removed from modified that were
+// present in original are deleted, and any key in current that neither
+// original nor modified know about (a foreign key added directly by another
+// actor) is kept. current itself is not modified.
+func StrategicMergeLabels(original, modified, current v1.Object) (map[string]string, error) {
+	if original == nil || modified == nil || current == nil {
+		return nil, errors.New("object cannot be nil")
+	}
+	return strategicMerge(original.GetLabels(), modified.GetLabels(), current.GetLabels()), nil
+}
+
+// StrategicMergeAnnotations is the annotation equivalent of
+// StrategicMergeLabels.
+func StrategicMergeAnnotations(original, modified, current v1.Object) (map[string]string, error) {
+	if original == nil || modified == nil || current == nil {
+		return nil, errors.New("object cannot be nil")
+	}
+	return strategicMerge(original.GetAnnotations(), modified.GetAnnotations(), current.GetAnnotations()), nil
+}
+
+// strategicMerge applies the diff between original and modified onto a copy
+// of current: additions and updates from modified win, and keys present in
+// original but dropped from modified are deleted, while keys current alone
+// knows about are preserved.
+func strategicMerge(original, modified, current map[string]string) map[string]string {
+	merged := make(map[string]string, len(current)+len(modified))
+	for key, val := range current {
+		merged[key] = val
+	}
+	for key, val := range modified {
+		merged[key] = val
+	}
+	for key := range original {
+		if _, stillDesired := modified[key]; !stillDesired {
+			delete(merged, key)
+		}
+	}
+	return merged
+}
+
+// SnapshotAppliedMetadata persists obj's current labels and annotations as a
+// JSON blob under the given annotation key, mirroring kubectl's
+// kubectl.kubernetes.io/last-applied-configuration pattern. Call this after a
+// successful reconcile so the next pass has an "original" side to diff
+// against in StrategicMergeLabels/StrategicMergeAnnotations. Unlike
+// AddAnnotation, this overwrites any existing value at key. Like kubectl's own
+// last-applied-configuration annotation, key itself is excluded from the
+// captured annotations so the snapshot never embeds a copy of itself across
+// reconcile rounds.
+func SnapshotAppliedMetadata(obj v1.Object, key string) error {
+	if obj == nil {
+		return errors.New("object cannot be nil")
+	}
+
+	data, err := json.Marshal(appliedMetadata{
+		Labels:      obj.GetLabels(),
+		Annotations: cloneWithout(obj.GetAnnotations(), key),
+	})
+	if err != nil {
+		return err
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[key] = string(data)
+	obj.SetAnnotations(annotations)
+	return nil
+}
+
+// cloneWithout returns a copy of entries with key removed.
+func cloneWithout(entries map[string]string, key string) map[string]string {
+	if len(entries) == 0 {
+		return nil
+	}
+	clone := make(map[string]string, len(entries))
+	for k, v := range entries {
+		if k != key {
+			clone[k] = v
+		}
+	}
+	return clone
+}
+
+// LoadAppliedMetadata reads back the labels/annotations snapshot previously
+// stored by SnapshotAppliedMetadata under key, returning it as a
+// *v1.ObjectMeta usable directly as the "original" side of
+// StrategicMergeLabels/StrategicMergeAnnotations. ok is false when no
+// snapshot is present at key.
+func LoadAppliedMetadata(obj v1.Object, key string) (original *v1.ObjectMeta, ok bool, err error) {
+	if obj == nil {
+		return nil, false, errors.New("object cannot be nil")
+	}
+
+	raw, found := obj.GetAnnotations()[key]
+	if !found {
+		return nil, false, nil
+	}
+
+	var snapshot appliedMetadata
+	if err := json.Unmarshal([]byte(raw), &snapshot); err != nil {
+		return nil, false, err
+	}
+
+	return &v1.ObjectMeta{Labels: snapshot.Labels, Annotations: snapshot.Annotations}, true, nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Merge", func() {
+
+	When("StrategicMergeLabels is called", func() {
+		It("should preserve foreign labels the user added directly on current", func() {
+			original := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"tier": "frontend"}}}
+			modified := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"tier": "frontend"}}}
+			current := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"tier": "frontend", "user-owned": "keep"}}}
+
+			merged, err := StrategicMergeLabels(original, modified, current)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(merged).To(gstruct.MatchAllKeys(gstruct.Keys{
+				"tier":       Equal("frontend"),
+				"user-owned": Equal("keep"),
+			}))
+		})
+
+		It("should delete labels the operator removed from the desired set", func() {
+			original := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"tier": "frontend", "legacy": "yes"}}}
+			modified := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"tier": "frontend"}}}
+			current := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"tier": "frontend", "legacy": "yes"}}}
+
+			merged, err := StrategicMergeLabels(original, modified, current)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(merged).To(HaveLen(1))
+			Expect(merged).NotTo(HaveKey("legacy"))
+		})
+
+		It("should let updated values win over stale current values", func() {
+			original := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"tier": "frontend"}}}
+			modified := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"tier": "backend"}}}
+			current := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"tier": "frontend"}}}
+
+			merged, err := StrategicMergeLabels(original, modified, current)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(merged).To(HaveKeyWithValue("tier", "backend"))
+		})
+
+		It("should error if any object is nil", func() {
+			pod := &corev1.Pod{}
+			_, err := StrategicMergeLabels(nil, pod, pod)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("object cannot be nil"))
+		})
+	})
+
+	When("StrategicMergeAnnotations is called", func() {
+		It("should behave like StrategicMergeLabels for annotations", func() {
+			original := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Annotations: map[string]string{"note": "old"}}}
+			modified := &corev1.Pod{ObjectMeta: v1.ObjectMeta{}}
+			current := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Annotations: map[string]string{"note": "old", "foreign": "keep"}}}
+
+			merged, err := StrategicMergeAnnotations(original, modified, current)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(merged).To(gstruct.MatchAllKeys(gstruct.Keys{
+				"foreign": Equal("keep"),
+			}))
+		})
+	})
+
+	When("SnapshotAppliedMetadata and LoadAppliedMetadata are called", func() {
+		It("should round-trip the object's labels and annotations", func() {
+			pod := &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{
+					Labels:      map[string]string{"tier": "frontend"},
+					Annotations: map[string]string{"note": "hello"},
+				},
+			}
+
+			Expect(SnapshotAppliedMetadata(pod, "operator-toolkit.io/last-applied-metadata")).To(Succeed())
+
+			original, ok, err := LoadAppliedMetadata(pod, "operator-toolkit.io/last-applied-metadata")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(original.Labels).To(HaveKeyWithValue("tier", "frontend"))
+			Expect(original.Annotations).To(HaveKeyWithValue("note", "hello"))
+		})
+
+		It("should overwrite a pre-existing snapshot rather than refusing to copy", func() {
+			pod := &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{
+					Labels:      map[string]string{"tier": "frontend"},
+					Annotations: map[string]string{"operator-toolkit.io/last-applied-metadata": "stale"},
+				},
+			}
+
+			Expect(SnapshotAppliedMetadata(pod, "operator-toolkit.io/last-applied-metadata")).To(Succeed())
+			Expect(pod.Annotations["operator-toolkit.io/last-applied-metadata"]).NotTo(Equal("stale"))
+		})
+
+		It("should report ok=false when no snapshot exists", func() {
+			pod := &corev1.Pod{}
+			_, ok, err := LoadAppliedMetadata(pod, "operator-toolkit.io/last-applied-metadata")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should error if the object is nil", func() {
+			Expect(SnapshotAppliedMetadata(nil, "key").Error()).To(Equal("object cannot be nil"))
+
+			_, _, err := LoadAppliedMetadata(nil, "key")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should not let the bookkeeping annotation recurse into its own snapshot across reconcile rounds", func() {
+			const key = "operator-toolkit.io/last-applied-metadata"
+			desired := map[string]string{"tier": "frontend"}
+			pod := &corev1.Pod{}
+
+			reconcile := func() {
+				original := &v1.ObjectMeta{}
+				if snapshot, ok, err := LoadAppliedMetadata(pod, key); err == nil && ok {
+					original = snapshot
+				}
+				modified := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Annotations: desired}}
+
+				merged, err := StrategicMergeAnnotations(original, modified, pod)
+				Expect(err).NotTo(HaveOccurred())
+				pod.SetAnnotations(merged)
+
+				Expect(SnapshotAppliedMetadata(pod, key)).To(Succeed())
+			}
+
+			reconcile()
+			reconcile()
+			reconcile()
+
+			Expect(pod.Annotations).To(HaveKeyWithValue("tier", "frontend"))
+			Expect(pod.Annotations).To(HaveKey(key))
+
+			snapshot, ok, err := LoadAppliedMetadata(pod, key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(snapshot.Annotations).NotTo(HaveKey(key))
+		})
+	})
+})
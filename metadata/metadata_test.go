@@ -81,6 +81,7 @@ var _ = Describe("Metadata", func() {
 			Expect(pod.Labels).To(HaveLen(1))
 			Expect(pod.Labels[label]).To(Equal(value))
 		})
+	})
 
 	When("AddLabels is called", func() {
 		It("should add the labels to the object", func() {
@@ -0,0 +1,228 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// CopiedFromAnnotationKey is the well-known annotation under which copies
+// made by CopyLabelsByPrefixWithProvenance/CopyAnnotationsByPrefixWithProvenance
+// record where an object's metadata came from, mirroring OLM's
+// IsCopied/OperatorGroupNamespaceAnnotationKey pattern.
+const CopiedFromAnnotationKey = "operator-toolkit.io/copied-from"
+
+// prefixMapping is the JSON-serializable record of a single prefix rewrite
+// applied by a provenance-tracked copy.
+type prefixMapping struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// provenanceRecord captures the source object a copy came from and the
+// prefix mappings applied from it, so the copy can be distinguished from
+// user-authored metadata and individually revoked.
+type provenanceRecord struct {
+	SourceNamespace string          `json:"sourceNamespace"`
+	SourceName      string          `json:"sourceName"`
+	SourceUID       string          `json:"sourceUID"`
+	PrefixMappings  []prefixMapping `json:"prefixMappings"`
+}
+
+// CopyLabelsByPrefixWithProvenance behaves like CopyLabelsByPrefix, then
+// records src's namespace/name/UID and the prefix mapping used under dest's
+// CopiedFromAnnotationKey annotation, so the copy can later be recognized via
+// IsCopy/CopySource and cleaned up via RemoveCopiedMetadata.
+func CopyLabelsByPrefixWithProvenance(src, dest v1.Object, prefix, replacementPrefix string) error {
+	if src == nil || dest == nil {
+		return errors.New("object cannot be nil")
+	}
+
+	CopyLabelsByPrefix(src, dest, prefix, replacementPrefix)
+	return appendProvenance(dest, src, prefixMapping{From: prefix, To: replacementPrefix})
+}
+
+// CopyAnnotationsByPrefixWithProvenance is the annotation equivalent of
+// CopyLabelsByPrefixWithProvenance.
+func CopyAnnotationsByPrefixWithProvenance(src, dest v1.Object, prefix, replacementPrefix string) error {
+	if src == nil || dest == nil {
+		return errors.New("object cannot be nil")
+	}
+
+	CopyAnnotationsByPrefix(src, dest, prefix, replacementPrefix)
+	return appendProvenance(dest, src, prefixMapping{From: prefix, To: replacementPrefix})
+}
+
+// CopyWithProvenance copies both labels and annotations matching prefix from
+// src to dest (see CopyLabelsByPrefixWithProvenance and
+// CopyAnnotationsByPrefixWithProvenance), recording a single provenance entry
+// for the pair since both calls record the same source and prefix mapping.
+func CopyWithProvenance(src, dest v1.Object, prefix, replacementPrefix string) error {
+	if src == nil || dest == nil {
+		return errors.New("object cannot be nil")
+	}
+
+	if err := CopyLabelsByPrefixWithProvenance(src, dest, prefix, replacementPrefix); err != nil {
+		return err
+	}
+	return CopyAnnotationsByPrefixWithProvenance(src, dest, prefix, replacementPrefix)
+}
+
+// IsCopy reports whether obj carries a CopiedFromAnnotationKey provenance
+// annotation, i.e. some of its metadata was produced by
+// CopyLabelsByPrefixWithProvenance/CopyAnnotationsByPrefixWithProvenance
+// rather than authored directly.
+func IsCopy(obj v1.Object) bool {
+	return HasAnnotation(obj, CopiedFromAnnotationKey)
+}
+
+// CopySource returns the namespace/name of the first source object recorded
+// in obj's provenance annotation, if any.
+func CopySource(obj v1.Object) (types.NamespacedName, bool) {
+	records, err := loadProvenance(obj)
+	if err != nil || len(records) == 0 {
+		return types.NamespacedName{}, false
+	}
+
+	source := records[0]
+	return types.NamespacedName{Namespace: source.SourceNamespace, Name: source.SourceName}, true
+}
+
+// RemoveCopiedMetadata revokes every recorded prefix mapping whose source
+// prefix equals sourcePrefix, across all recorded sources: it deletes every
+// label and annotation whose key starts with that mapping's destination
+// prefix, and removes the mapping from obj's provenance annotation. A source
+// entry is dropped once it has no mappings left, and the annotation itself is
+// removed once no entries remain, so callers can clean up after the source
+// object disappears.
+func RemoveCopiedMetadata(obj v1.Object, sourcePrefix string) error {
+	if obj == nil {
+		return errors.New("object cannot be nil")
+	}
+
+	records, err := loadProvenance(obj)
+	if err != nil || len(records) == 0 {
+		return err
+	}
+
+	remaining := make([]provenanceRecord, 0, len(records))
+	for _, record := range records {
+		mappings := make([]prefixMapping, 0, len(record.PrefixMappings))
+		for _, mapping := range record.PrefixMappings {
+			if mapping.From != sourcePrefix {
+				mappings = append(mappings, mapping)
+				continue
+			}
+			removeByPrefix(obj.GetLabels(), mapping.To)
+			removeByPrefix(obj.GetAnnotations(), mapping.To)
+		}
+		if len(mappings) > 0 {
+			record.PrefixMappings = mappings
+			remaining = append(remaining, record)
+		}
+	}
+
+	return saveProvenance(obj, remaining)
+}
+
+// removeByPrefix deletes every entry in entries whose key has the given
+// prefix.
+func removeByPrefix(entries map[string]string, prefix string) {
+	for key := range entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(entries, key)
+		}
+	}
+}
+
+// appendProvenance records a copy from src onto dest, merging into src's
+// existing provenanceRecord (matched by namespace/name/UID) if one is already
+// present, or appending a new one otherwise. Calling this repeatedly with the
+// same source and prefix mapping - the normal case for a copy helper invoked
+// every reconcile pass - is idempotent: an already-recorded {From, To} pair
+// is not duplicated.
+func appendProvenance(dest, src v1.Object, mapping prefixMapping) error {
+	records, err := loadProvenance(dest)
+	if err != nil {
+		return err
+	}
+
+	uid := string(src.GetUID())
+	for i, record := range records {
+		if record.SourceNamespace != src.GetNamespace() || record.SourceName != src.GetName() || record.SourceUID != uid {
+			continue
+		}
+		for _, existing := range record.PrefixMappings {
+			if existing == mapping {
+				return nil
+			}
+		}
+		records[i].PrefixMappings = append(records[i].PrefixMappings, mapping)
+		return saveProvenance(dest, records)
+	}
+
+	records = append(records, provenanceRecord{
+		SourceNamespace: src.GetNamespace(),
+		SourceName:      src.GetName(),
+		SourceUID:       uid,
+		PrefixMappings:  []prefixMapping{mapping},
+	})
+	return saveProvenance(dest, records)
+}
+
+// loadProvenance reads and decodes obj's CopiedFromAnnotationKey annotation,
+// returning a nil slice when the annotation is absent.
+func loadProvenance(obj v1.Object) ([]provenanceRecord, error) {
+	raw, ok := obj.GetAnnotations()[CopiedFromAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var records []provenanceRecord
+	if err := json.Unmarshal([]byte(raw), &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// saveProvenance encodes records back onto obj's CopiedFromAnnotationKey
+// annotation, removing the annotation entirely when records is empty.
+func saveProvenance(obj v1.Object, records []provenanceRecord) error {
+	if len(records) == 0 {
+		annotations := obj.GetAnnotations()
+		delete(annotations, CopiedFromAnnotationKey)
+		return nil
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[CopiedFromAnnotationKey] = string(data)
+	obj.SetAnnotations(annotations)
+	return nil
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("Provenance", func() {
+
+	When("CopyLabelsByPrefixWithProvenance is called", func() {
+		It("should copy the labels and record where they came from", func() {
+			src := &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{
+					Namespace: "source-ns",
+					Name:      "source-pod",
+					UID:       "abc-123",
+					Labels:    map[string]string{"olm.owner": "csv-a"},
+				},
+			}
+			dest := &corev1.Pod{}
+
+			Expect(CopyLabelsByPrefixWithProvenance(src, dest, "olm.", "copied.olm.")).To(Succeed())
+
+			Expect(dest.Labels).To(HaveKeyWithValue("copied.olm.owner", "csv-a"))
+			Expect(IsCopy(dest)).To(BeTrue())
+
+			source, ok := CopySource(dest)
+			Expect(ok).To(BeTrue())
+			Expect(source).To(Equal(types.NamespacedName{Namespace: "source-ns", Name: "source-pod"}))
+		})
+
+		It("should error if either object is nil", func() {
+			err := CopyLabelsByPrefixWithProvenance(nil, &corev1.Pod{}, "a", "b")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("object cannot be nil"))
+		})
+
+		It("should not duplicate the prefix mapping when called repeatedly for the same source", func() {
+			src := &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{
+					Namespace: "source-ns",
+					Name:      "source-pod",
+					UID:       "abc-123",
+					Labels:    map[string]string{"olm.owner": "csv-a"},
+				},
+			}
+			dest := &corev1.Pod{}
+
+			for i := 0; i < 5; i++ {
+				Expect(CopyLabelsByPrefixWithProvenance(src, dest, "olm.", "copied.olm.")).To(Succeed())
+			}
+
+			records, err := loadProvenance(dest)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(HaveLen(1))
+			Expect(records[0].PrefixMappings).To(HaveLen(1))
+		})
+	})
+
+	When("CopyWithProvenance is called", func() {
+		It("should copy both labels and annotations and record a single provenance entry", func() {
+			src := &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{
+					Namespace:   "source-ns",
+					Name:        "source-pod",
+					UID:         "abc-123",
+					Labels:      map[string]string{"olm.owner": "csv-a"},
+					Annotations: map[string]string{"olm.owner": "csv-a"},
+				},
+			}
+			dest := &corev1.Pod{}
+
+			Expect(CopyWithProvenance(src, dest, "olm.", "copied.olm.")).To(Succeed())
+
+			Expect(dest.Labels).To(HaveKeyWithValue("copied.olm.owner", "csv-a"))
+			Expect(dest.Annotations).To(HaveKeyWithValue("copied.olm.owner", "csv-a"))
+
+			records, err := loadProvenance(dest)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(HaveLen(1))
+			Expect(records[0].PrefixMappings).To(HaveLen(1))
+		})
+
+		It("should error if either object is nil", func() {
+			err := CopyWithProvenance(nil, &corev1.Pod{}, "a", "b")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("object cannot be nil"))
+		})
+	})
+
+	When("IsCopy is called", func() {
+		It("should return false for an object with no provenance annotation", func() {
+			Expect(IsCopy(&corev1.Pod{})).To(BeFalse())
+		})
+	})
+
+	When("RemoveCopiedMetadata is called", func() {
+		It("should delete the copied labels and drop the provenance record", func() {
+			src := &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{
+					Namespace: "source-ns",
+					Name:      "source-pod",
+					UID:       "abc-123",
+					Labels:    map[string]string{"olm.owner": "csv-a"},
+				},
+			}
+			dest := &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{
+					Labels: map[string]string{"user-owned": "keep"},
+				},
+			}
+
+			Expect(CopyLabelsByPrefixWithProvenance(src, dest, "olm.", "copied.olm.")).To(Succeed())
+			Expect(RemoveCopiedMetadata(dest, "olm.")).To(Succeed())
+
+			Expect(dest.Labels).To(HaveKeyWithValue("user-owned", "keep"))
+			Expect(dest.Labels).NotTo(HaveKey("copied.olm.owner"))
+			Expect(IsCopy(dest)).To(BeFalse())
+		})
+
+		It("should be a no-op when obj has no provenance annotation", func() {
+			dest := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"tier": "frontend"}}}
+			Expect(RemoveCopiedMetadata(dest, "olm.")).To(Succeed())
+			Expect(dest.Labels).To(HaveKeyWithValue("tier", "frontend"))
+		})
+
+		It("should error if the object is nil", func() {
+			err := RemoveCopiedMetadata(nil, "olm.")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("object cannot be nil"))
+		})
+	})
+})
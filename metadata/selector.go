@@ -0,0 +1,292 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Operator is the comparison operator of a selector Requirement.
+type Operator string
+
+const (
+	Equals       Operator = "="
+	NotEquals    Operator = "!="
+	In           Operator = "in"
+	NotIn        Operator = "notin"
+	Exists       Operator = "exists"
+	DoesNotExist Operator = "!"
+)
+
+// Requirement is a single parsed clause of a label-selector expression, e.g.
+// "tier in (frontend,backend)" or "!deprecated".
+type Requirement struct {
+	Key    string
+	Op     Operator
+	Values []string
+}
+
+// matches evaluates the Requirement against a set of key/value pairs.
+func (r Requirement) matches(entries map[string]string) bool {
+	val, ok := entries[r.Key]
+	switch r.Op {
+	case Exists:
+		return ok
+	case DoesNotExist:
+		return !ok
+	case Equals, In:
+		if !ok {
+			return false
+		}
+		for _, v := range r.Values {
+			if v == val {
+				return true
+			}
+		}
+		return false
+	case NotEquals, NotIn:
+		if !ok {
+			return true
+		}
+		for _, v := range r.Values {
+			if v == val {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// Selector is a compiled, reusable label/annotation selector. Build one with
+// ParseSelector and reuse it across objects so reconciler hot paths don't
+// re-parse the same expression per object.
+type Selector struct {
+	requirements []Requirement
+}
+
+// Matches reports whether entries satisfies every Requirement in the
+// Selector, short-circuiting on the first Requirement that fails.
+func (s *Selector) Matches(entries map[string]string) bool {
+	for _, requirement := range s.requirements {
+		if !requirement.matches(entries) {
+			return false
+		}
+	}
+	return true
+}
+
+// clause is a single comma-separated segment of a selector expression,
+// together with the offset at which it starts, for error reporting.
+type clause struct {
+	text  string
+	start int
+}
+
+// ParseSelector parses a Kubernetes label-selector expression into a reusable
+// Selector. The full grammar is supported: equality (=, ==, !=), set-based
+// (in, notin), existence (key, !key), conjoined with commas.
+func ParseSelector(selector string) (*Selector, error) {
+	trimmed := strings.TrimSpace(selector)
+	if trimmed == "" {
+		return &Selector{}, nil
+	}
+
+	clauses, err := splitClauses(trimmed)
+	if err != nil {
+		return nil, err
+	}
+
+	requirements := make([]Requirement, 0, len(clauses))
+	for _, c := range clauses {
+		requirement, err := parseRequirement(c.text)
+		if err != nil {
+			return nil, fmt.Errorf("selector %q: position %d: %w", selector, c.start, err)
+		}
+		requirements = append(requirements, requirement)
+	}
+
+	return &Selector{requirements: requirements}, nil
+}
+
+// splitClauses splits a selector expression on top-level commas, i.e. commas
+// that do not fall inside a set-based "(...)" value list.
+func splitClauses(selector string) ([]clause, error) {
+	var clauses []clause
+	depth := 0
+	start := 0
+
+	for i, r := range selector {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unmatched ')' at position %d", i)
+			}
+		case ',':
+			if depth == 0 {
+				clauses = append(clauses, clause{text: selector[start:i], start: start})
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, errors.New("unmatched '(' in selector")
+	}
+
+	clauses = append(clauses, clause{text: selector[start:], start: start})
+	return clauses, nil
+}
+
+// setBasedRequirement matches a "key in (v1,v2)" or "key notin (v1,v2)" clause.
+var setBasedRequirement = func() func(string) (key, op, rawValues string, ok bool) {
+	return func(text string) (string, string, string, bool) {
+		for _, op := range []string{"in", "notin"} {
+			sep := " " + op + " "
+			idx := strings.Index(text, sep)
+			if idx < 0 {
+				continue
+			}
+			key := strings.TrimSpace(text[:idx])
+			rest := strings.TrimSpace(text[idx+len(sep):])
+			if !strings.HasPrefix(rest, "(") || !strings.HasSuffix(rest, ")") {
+				continue
+			}
+			return key, op, rest[1 : len(rest)-1], true
+		}
+		return "", "", "", false
+	}
+}()
+
+// parseRequirement parses a single clause (with the enclosing commas already
+// stripped) into a Requirement.
+func parseRequirement(text string) (Requirement, error) {
+	clause := strings.TrimSpace(text)
+	if clause == "" {
+		return Requirement{}, errors.New("empty requirement")
+	}
+
+	if strings.HasPrefix(clause, "!") && !strings.HasPrefix(clause, "!=") {
+		key := strings.TrimSpace(clause[1:])
+		if err := validateKey(key); err != nil {
+			return Requirement{}, err
+		}
+		return Requirement{Key: key, Op: DoesNotExist}, nil
+	}
+
+	if key, op, rawValues, ok := setBasedRequirement(clause); ok {
+		if err := validateKey(key); err != nil {
+			return Requirement{}, err
+		}
+		values := splitValues(rawValues)
+		if len(values) == 0 {
+			return Requirement{}, fmt.Errorf("%q requires at least one value", op)
+		}
+		selectorOp := In
+		if op == "notin" {
+			selectorOp = NotIn
+		}
+		return Requirement{Key: key, Op: selectorOp, Values: values}, nil
+	}
+
+	for _, binary := range []struct {
+		token string
+		op    Operator
+	}{
+		{"!=", NotEquals},
+		{"==", Equals},
+		{"=", Equals},
+	} {
+		idx := strings.Index(clause, binary.token)
+		if idx < 0 {
+			continue
+		}
+		key := strings.TrimSpace(clause[:idx])
+		value := strings.TrimSpace(clause[idx+len(binary.token):])
+		if key == "" || value == "" {
+			return Requirement{}, fmt.Errorf("malformed requirement %q", clause)
+		}
+		if err := validateKey(key); err != nil {
+			return Requirement{}, err
+		}
+		return Requirement{Key: key, Op: binary.op, Values: []string{value}}, nil
+	}
+
+	if err := validateKey(clause); err != nil {
+		return Requirement{}, err
+	}
+	return Requirement{Key: clause, Op: Exists}, nil
+}
+
+// splitValues splits a comma-separated "(v1,v2,v3)" value list, trimming
+// whitespace and dropping empty entries.
+func splitValues(raw string) []string {
+	var values []string
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// validateKey rejects keys that cannot occur in a selector, such as empty or
+// whitespace-containing keys.
+func validateKey(key string) error {
+	if key == "" {
+		return errors.New("missing key")
+	}
+	if strings.ContainsAny(key, " \t") {
+		return fmt.Errorf("invalid key %q", key)
+	}
+	return nil
+}
+
+// MatchesSelector parses selector and evaluates it against obj's labels.
+func MatchesSelector(obj v1.Object, selector string) (bool, error) {
+	if obj == nil {
+		return false, errors.New("object cannot be nil")
+	}
+
+	compiled, err := ParseSelector(selector)
+	if err != nil {
+		return false, err
+	}
+	return compiled.Matches(obj.GetLabels()), nil
+}
+
+// SelectAnnotations parses selector and evaluates it against obj's
+// annotations.
+func SelectAnnotations(obj v1.Object, selector string) (bool, error) {
+	if obj == nil {
+		return false, errors.New("object cannot be nil")
+	}
+
+	compiled, err := ParseSelector(selector)
+	if err != nil {
+		return false, err
+	}
+	return compiled.Matches(obj.GetAnnotations()), nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Selector", func() {
+
+	When("ParseSelector is called", func() {
+		It("should parse equality, set-based and existence requirements together", func() {
+			selector, err := ParseSelector("tier in (frontend,backend),env!=dev,!deprecated,name=api")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selector.requirements).To(HaveLen(4))
+		})
+
+		It("should return an empty selector for an empty string", func() {
+			selector, err := ParseSelector("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selector.requirements).To(BeEmpty())
+		})
+
+		It("should error on an unmatched '('", func() {
+			_, err := ParseSelector("tier in (frontend,backend")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should error on an unmatched ')'", func() {
+			_, err := ParseSelector("tier)")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("position"))
+		})
+
+		It("should error on a malformed equality requirement", func() {
+			_, err := ParseSelector("=value")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should error on a malformed '!=' requirement instead of parsing it as negated existence", func() {
+			_, err := ParseSelector("!=dev")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("Matches is called", func() {
+		entries := map[string]string{"tier": "frontend", "env": "prod"}
+
+		It("should match an equality requirement", func() {
+			selector, err := ParseSelector("tier=frontend")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selector.Matches(entries)).To(BeTrue())
+		})
+
+		It("should match a negated equality requirement", func() {
+			selector, err := ParseSelector("tier!=backend")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selector.Matches(entries)).To(BeTrue())
+		})
+
+		It("should match a set-based requirement", func() {
+			selector, err := ParseSelector("tier in (frontend,backend)")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selector.Matches(entries)).To(BeTrue())
+		})
+
+		It("should match a notin requirement when the key is absent", func() {
+			selector, err := ParseSelector("missing notin (x,y)")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selector.Matches(entries)).To(BeTrue())
+		})
+
+		It("should match an existence requirement", func() {
+			selector, err := ParseSelector("tier")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selector.Matches(entries)).To(BeTrue())
+		})
+
+		It("should match a non-existence requirement", func() {
+			selector, err := ParseSelector("!missing")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selector.Matches(entries)).To(BeTrue())
+		})
+
+		It("should not match when any requirement fails", func() {
+			selector, err := ParseSelector("tier=frontend,env=dev")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selector.Matches(entries)).To(BeFalse())
+		})
+	})
+
+	When("MatchesSelector is called", func() {
+		It("should evaluate the selector against the object's labels", func() {
+			pod := &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{
+					Labels: map[string]string{"tier": "frontend", "env": "prod"},
+				},
+			}
+
+			matched, err := MatchesSelector(pod, "tier in (frontend,backend),env!=dev,!deprecated")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(matched).To(BeTrue())
+		})
+
+		It("should error if the object is nil", func() {
+			_, err := MatchesSelector(nil, "tier=frontend")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("object cannot be nil"))
+		})
+	})
+
+	When("SelectAnnotations is called", func() {
+		It("should evaluate the selector against the object's annotations", func() {
+			pod := &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{
+					Annotations: map[string]string{"tier": "backend"},
+				},
+			}
+
+			matched, err := SelectAnnotations(pod, "tier in (frontend,backend)")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(matched).To(BeTrue())
+		})
+
+		It("should error if the object is nil", func() {
+			_, err := SelectAnnotations(nil, "tier=frontend")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("object cannot be nil"))
+		})
+	})
+})